@@ -0,0 +1,52 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"github.com/soumya92/barista/bar"
+)
+
+// Sink adapts the update-tracking closure ModuleSet builds in sinkFn to the
+// bar.Sink interface expected by a bar.Module.
+type Sink func(bar.Segments)
+
+// Output implements bar.Sink.
+func (s Sink) Output(segments bar.Segments) {
+	s(segments)
+}
+
+// Module wraps a bar.Module with click routing.
+type Module struct {
+	wrapped bar.Module
+}
+
+// NewModule wraps m for use in a ModuleSet.
+func NewModule(m bar.Module) *Module {
+	return &Module{wrapped: m}
+}
+
+// Stream starts the wrapped module. bar.Module has no single-method
+// signature that could carry a context (it's also required to satisfy
+// bar.Module's plain Stream(bar.Sink) elsewhere), so there's no way to tell
+// it to stop; ModuleSet.stop() accounts for that by not waiting on this
+// call to return.
+func (m *Module) Stream(sink Sink) {
+	m.wrapped.Stream(sink)
+}
+
+// Click forwards a click event to the wrapped module.
+func (m *Module) Click(e bar.Event) {
+	m.wrapped.Click(e)
+}
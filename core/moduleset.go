@@ -15,23 +15,62 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/soumya92/barista/bar"
+
+	"barista.run/timing"
 )
 
+// ErrAlreadyStarted is returned by Stream when the ModuleSet is already
+// streaming updates.
+var ErrAlreadyStarted = errors.New("core: module set already started")
+
+// ErrAlreadyStopped is returned by Stop when the ModuleSet isn't currently
+// streaming updates.
+var ErrAlreadyStopped = errors.New("core: module set already stopped")
+
 type ModuleSet struct {
 	modules   []*Module
-	updateCh  chan int
 	outputs   []bar.Segments
 	outputsMu sync.RWMutex
+	opts      ModuleSetOptions
+
+	mu       sync.Mutex
+	running  bool
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	updateCh chan int
+}
+
+// ModuleSetOptions configures per-module update rate limiting for a
+// ModuleSet, so a module that emits updates in a burst (e.g. a DBus watcher
+// during a rapid state change) doesn't flood the bar with renders.
+type ModuleSetOptions struct {
+	// MaxUpdatesPerSec caps how often a single module's update is forwarded
+	// on the ModuleSet's channel. Zero means unlimited, i.e. every update is
+	// forwarded as soon as it arrives.
+	MaxUpdatesPerSec int
+	// Burst allows this many updates through immediately before throttling
+	// kicks in. Values less than 1 are treated as 1. Ignored when
+	// MaxUpdatesPerSec is 0.
+	Burst int
 }
 
 func NewModuleSet(modules []bar.Module) *ModuleSet {
+	return NewModuleSetWithOptions(modules, ModuleSetOptions{})
+}
+
+// NewModuleSetWithOptions is like NewModuleSet, but rate-limits each
+// module's updates according to opts.
+func NewModuleSetWithOptions(modules []bar.Module, opts ModuleSetOptions) *ModuleSet {
 	set := &ModuleSet{
-		modules:  make([]*Module, len(modules)),
-		outputs:  make([]bar.Segments, len(modules)),
-		updateCh: make(chan int),
+		modules: make([]*Module, len(modules)),
+		outputs: make([]bar.Segments, len(modules)),
+		opts:    opts,
 	}
 	for i, m := range modules {
 		set.modules[i] = NewModule(m)
@@ -39,19 +78,189 @@ func NewModuleSet(modules []bar.Module) *ModuleSet {
 	return set
 }
 
-func (set *ModuleSet) Stream() <-chan int {
-	for i, m := range set.modules {
-		go m.Stream(set.sinkFn(i))
+// Stream starts every module in the set and returns the channel on which
+// their updates (by module index) are delivered. It returns
+// ErrAlreadyStarted if the set is already streaming; call Stop (or Restart)
+// before streaming again.
+func (set *ModuleSet) Stream() (<-chan int, error) {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	if set.running {
+		return nil, ErrAlreadyStarted
+	}
+	set.start()
+	return set.updateCh, nil
+}
+
+// Stop cancels the context shared by every module's rate-limiting pump and
+// closes the update channel returned by Stream, once every pump has exited.
+// bar.Module has no way to be told to stop, so a module's own Stream
+// goroutine may keep running after Stop returns; its updates are simply
+// dropped on the floor (sinkFn's send is ctx-aware, so this can't deadlock
+// it). Stop returns ErrAlreadyStopped if the set isn't currently streaming.
+func (set *ModuleSet) Stop() error {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	if !set.running {
+		return ErrAlreadyStopped
+	}
+	set.stop()
+	close(set.updateCh)
+	set.updateCh = nil
+	return nil
+}
+
+// Restart stops the set (if running) and streams it again with fresh
+// outputs, so stale segments from before the restart aren't shown alongside
+// updates from the new run. Callers can fetch the new channel with Updates.
+func (set *ModuleSet) Restart() error {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	if set.running {
+		set.stop()
+	}
+	set.outputsMu.Lock()
+	for i := range set.outputs {
+		set.outputs[i] = nil
 	}
+	set.outputsMu.Unlock()
+	set.start()
+	return nil
+}
+
+// Updates returns the channel currently in use for update notifications, as
+// last returned by Stream. It's most useful after a Restart, since that
+// replaces the channel without handing it back directly.
+func (set *ModuleSet) Updates() <-chan int {
+	set.mu.Lock()
+	defer set.mu.Unlock()
 	return set.updateCh
 }
 
-func (m *ModuleSet) sinkFn(idx int) Sink {
+// start launches every module's Stream (and its rate-limiting pump) under a
+// fresh context, and marks the set as running. Callers must hold set.mu.
+func (set *ModuleSet) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	set.cancel = cancel
+	if set.updateCh != nil {
+		// A previous run's channel (from Restart) is being superseded;
+		// close it so anyone still ranging over it unblocks instead of
+		// waiting forever on a channel nothing will ever write to again.
+		close(set.updateCh)
+	}
+	set.updateCh = make(chan int)
+	set.running = true
+	for i, m := range set.modules {
+		pending := make(chan int)
+		// Not tracked by set.wg: bar.Module has no way to be told to stop,
+		// so this goroutine may outlive ctx being canceled. See stop().
+		go func(i int, m *Module) {
+			defer close(pending)
+			m.Stream(set.sinkFn(ctx, i, pending))
+		}(i, m)
+		set.wg.Add(1)
+		go func(i int) {
+			defer set.wg.Done()
+			set.pump(ctx, i, pending)
+		}(i)
+	}
+}
+
+// stop cancels the running context and waits for every rate-limiting pump
+// to exit, but leaves updateCh open: Stop closes it afterwards, and on
+// Restart the following start() closes and replaces it. Only
+// the pumps are waited on: they always return once ctx is done, whereas a
+// module's own Stream may not, so waiting on it here could block forever.
+// Callers must hold set.mu and know the set is running.
+func (set *ModuleSet) stop() {
+	set.cancel()
+	set.wg.Wait()
+	set.running = false
+}
+
+// sinkFn builds the Sink passed to a module's Stream. The send to pending is
+// ctx-aware so that if pump has already exited because ctx was canceled (or
+// the module keeps running past Stop, since bar.Module can't be told to
+// stop), the module's call to sink.Output doesn't block forever waiting for
+// a reader that's gone.
+func (set *ModuleSet) sinkFn(ctx context.Context, idx int, pending chan<- int) Sink {
 	return func(out bar.Segments) {
-		m.outputsMu.Lock()
-		m.outputs[idx] = out
-		m.outputsMu.Unlock()
-		m.updateCh <- idx
+		set.outputsMu.Lock()
+		if ctx.Err() != nil {
+			// A previous run's module: don't let it overwrite outputs.
+			set.outputsMu.Unlock()
+			return
+		}
+		set.outputs[idx] = out
+		set.outputsMu.Unlock()
+		select {
+		case pending <- idx:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// pump forwards updates for module idx from pending to updateCh, rate
+// limited to opts.MaxUpdatesPerSec with an initial burst of opts.Burst
+// immediate emits. Once the burst is spent, pump sleeps until the next
+// allowed slot, coalescing any updates that arrive in the meantime since
+// only the most recent output (already stored in set.outputs by sinkFn)
+// matters once it's time to emit. pump exits once ctx is done or pending is
+// closed, whichever happens first.
+func (set *ModuleSet) pump(ctx context.Context, idx int, pending <-chan int) {
+	rate := set.opts.MaxUpdatesPerSec
+	interval := time.Duration(0)
+	burst := 1
+	if rate > 0 {
+		interval = time.Second / time.Duration(rate)
+		if set.opts.Burst > 1 {
+			burst = set.opts.Burst
+		}
+	}
+	tokens := burst
+	var nextSlot time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-pending:
+			if !ok {
+				return
+			}
+		}
+		if rate > 0 {
+			if tokens > 0 {
+				tokens--
+			} else if wait := nextSlot.Sub(timing.Now()); wait > 0 {
+				if !set.coalesceUntil(ctx, pending, timing.After(wait)) {
+					return
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case set.updateCh <- idx:
+		}
+		nextSlot = timing.Now().Add(interval)
+	}
+}
+
+// coalesceUntil drains pending, discarding updates, until timer fires or ctx
+// is done. It returns false if pending was closed or ctx was canceled
+// before the timer fired, signalling that the caller should stop.
+func (set *ModuleSet) coalesceUntil(ctx context.Context, pending <-chan int, timer <-chan time.Time) bool {
+	for {
+		select {
+		case <-timer:
+			return true
+		case <-ctx.Done():
+			return false
+		case _, ok := <-pending:
+			if !ok {
+				return false
+			}
+		}
 	}
 }
 
@@ -0,0 +1,163 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/soumya92/barista/bar"
+
+	"barista.run/timing"
+)
+
+// stubModule is a minimal bar.Module for tests: it signals when Stream
+// starts, emits a segment whenever triggered, and only returns once done is
+// closed -- so tests can model both cooperative modules and ones (like
+// systemd.ServiceModule) that never return from Stream on their own.
+type stubModule struct {
+	sink    bar.Sink
+	started chan struct{}
+	trigger chan struct{}
+	done    chan struct{}
+}
+
+func newStubModule() *stubModule {
+	return &stubModule{
+		started: make(chan struct{}),
+		trigger: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+func (s *stubModule) Stream(sink bar.Sink) {
+	s.sink = sink
+	close(s.started)
+	for {
+		select {
+		case <-s.trigger:
+			sink.Output(bar.Segments{})
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *stubModule) Click(bar.Event) {}
+
+func waitFor(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func TestModuleSet_StreamDeliversUpdates(t *testing.T) {
+	mod := newStubModule()
+	set := NewModuleSet([]bar.Module{mod})
+	defer close(mod.done)
+
+	ch, err := set.Stream()
+	require.NoError(t, err)
+	waitFor(t, mod.started, "module to start")
+
+	mod.trigger <- struct{}{}
+	select {
+	case idx := <-ch:
+		require.Equal(t, 0, idx)
+	case <-time.After(time.Second):
+		t.Fatal("did not receive update")
+	}
+}
+
+func TestModuleSet_StreamAlreadyStarted(t *testing.T) {
+	mod := newStubModule()
+	set := NewModuleSet([]bar.Module{mod})
+	defer close(mod.done)
+
+	_, err := set.Stream()
+	require.NoError(t, err)
+
+	_, err = set.Stream()
+	require.Equal(t, ErrAlreadyStarted, err)
+}
+
+func TestModuleSet_StopAlreadyStopped(t *testing.T) {
+	set := NewModuleSet([]bar.Module{newStubModule()})
+	require.Equal(t, ErrAlreadyStopped, set.Stop())
+}
+
+// TestModuleSet_StopDoesNotBlockOnWrappedModule guards against the
+// regression where Stop waited on every wrapped module's Stream goroutine,
+// not just the rate-limiting pumps -- since bar.Module (e.g.
+// systemd.ServiceModule) has no way to be told to stop, that wait never
+// returned.
+func TestModuleSet_StopDoesNotBlockOnWrappedModule(t *testing.T) {
+	mod := newStubModule() // mod.done is deliberately never closed.
+	set := NewModuleSet([]bar.Module{mod})
+
+	_, err := set.Stream()
+	require.NoError(t, err)
+	waitFor(t, mod.started, "module to start")
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- set.Stop() }()
+
+	select {
+	case err := <-stopped:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Stop blocked on a module that can't be told to stop")
+	}
+}
+
+func TestModuleSet_RateLimiting(t *testing.T) {
+	timing.TestMode()
+	mod := newStubModule()
+	set := NewModuleSetWithOptions([]bar.Module{mod}, ModuleSetOptions{MaxUpdatesPerSec: 1})
+	defer close(mod.done)
+
+	ch, err := set.Stream()
+	require.NoError(t, err)
+	waitFor(t, mod.started, "module to start")
+
+	mod.trigger <- struct{}{}
+	waitFor(t, chanToStruct(ch), "first update to pass through immediately")
+
+	// A second update arriving within the same second is throttled until
+	// the next allowed slot.
+	mod.trigger <- struct{}{}
+	select {
+	case <-ch:
+		t.Fatal("second update was not rate limited")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	timing.AdvanceBy(time.Second)
+	waitFor(t, chanToStruct(ch), "throttled update to arrive after advancing")
+}
+
+func chanToStruct(ch <-chan int) <-chan struct{} {
+	out := make(chan struct{})
+	go func() {
+		<-ch
+		close(out)
+	}()
+	return out
+}
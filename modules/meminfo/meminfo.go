@@ -17,6 +17,7 @@ package meminfo
 
 import (
 	"bufio"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -58,21 +59,60 @@ func (i Info) AvailFrac() float64 {
 	return float64(i.Available()) / float64(i["MemTotal"])
 }
 
+// CGroupAvailable returns the memory available to the current cgroup,
+// preferring the cgroup-v2 accounting (populated via CGroup* keys) when
+// present, falling back to the system-wide Available() otherwise.
+func (i Info) CGroupAvailable() unit.Datasize {
+	current, ok := i["CGroupMemCurrent"]
+	if !ok {
+		return i.Available()
+	}
+	max := i.cgroupMemMax()
+	if max < current {
+		return 0
+	}
+	return max - current
+}
+
+// CGroupAvailFrac returns the cgroup-aware available memory as a fraction of
+// its limit, falling back to MemTotal when the cgroup has no limit of its own.
+func (i Info) CGroupAvailFrac() float64 {
+	max := i.cgroupMemMax()
+	if max == 0 {
+		return 0
+	}
+	return float64(i.CGroupAvailable()) / float64(max)
+}
+
+// cgroupMemMax returns memory.max, treating the "max" (unlimited) case as
+// the host's MemTotal so fraction-based metrics stay meaningful.
+func (i Info) cgroupMemMax() unit.Datasize {
+	if max, ok := i["CGroupMemMax"]; ok {
+		return max
+	}
+	return i["MemTotal"]
+}
+
 // Module represents a meminfo multi-module, and provides an interface
 // for creating bar.Modules with various output functions/templates
 // that share the same data source, cutting down on updates required.
 type Module struct {
 	sync.Mutex
-	moduleSet *multi.ModuleSet
-	outputs   map[multi.Submodule]func(Info) bar.Output
-	scheduler scheduler.Scheduler
+	moduleSet  *multi.ModuleSet
+	outputs    map[multi.Submodule]func(Info) bar.Output
+	scheduler  scheduler.Scheduler
+	cgroupPath string
+	// autoDetect is false once Cgroup has been called explicitly, so
+	// update() doesn't clobber a user-pinned path with auto-detection.
+	autoDetect bool
 }
 
 // New constructs an instance of the meminfo multi-module
 func New() *Module {
 	m := &Module{
-		moduleSet: multi.NewModuleSet(),
-		outputs:   make(map[multi.Submodule]func(Info) bar.Output),
+		moduleSet:  multi.NewModuleSet(),
+		outputs:    make(map[multi.Submodule]func(Info) bar.Output),
+		autoDetect: true,
 	}
 	// Update meminfo when asked.
 	m.moduleSet.OnUpdate(m.update)
@@ -89,6 +129,17 @@ func (m *Module) RefreshInterval(interval time.Duration) *Module {
 	return m
 }
 
+// Cgroup pins meminfo to the cgroup-v2 accounting files under the given
+// directory (e.g. "/sys/fs/cgroup/user.slice/user-1000.slice"), instead of
+// auto-detecting the current process's cgroup from /proc/self/cgroup.
+func (m *Module) Cgroup(path string) *Module {
+	m.Lock()
+	defer m.Unlock()
+	m.cgroupPath = path
+	m.autoDetect = false
+	return m
+}
+
 // OutputFunc creates a submodule that displays the output of a user-defined function.
 func (m *Module) OutputFunc(format func(Info) bar.Output) base.WithClickHandler {
 	m.Lock()
@@ -135,9 +186,109 @@ func (m *Module) update() {
 		}
 		i[name] = unit.Datasize(intval) * mult
 	}
+
+	m.Lock()
+	cgroupPath := m.cgroupPath
+	autoDetect := m.autoDetect
+	m.Unlock()
+	if cgroupPath == "" && autoDetect {
+		cgroupPath = detectCgroup()
+	}
+	if cgroupPath != "" {
+		addCgroupInfo(i, cgroupPath)
+	}
+
 	m.Lock()
 	defer m.Unlock()
 	for submodule, outputFunc := range m.outputs {
 		submodule.Output(outputFunc(i))
 	}
 }
+
+// cgroupRoot is the standard mountpoint of the unified (v2) cgroup hierarchy.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// detectCgroup resolves the current process's cgroup-v2 directory from
+// /proc/self/cgroup. It returns "" if the host isn't using the unified
+// hierarchy, matching the "no cgroup info available" case.
+func detectCgroup() string {
+	f, err := fs.Open("/proc/self/cgroup")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	s.Split(bufio.ScanLines)
+	for s.Scan() {
+		// Unified hierarchy lines look like "0::/user.slice/user-1000.slice/...".
+		line := strings.TrimSpace(s.Text())
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) == 3 && parts[0] == "0" && parts[1] == "" {
+			return filepath.Join(cgroupRoot, parts[2])
+		}
+	}
+	return ""
+}
+
+// cgroupStatKeys maps the fields of memory.stat that we surface to the Info
+// key they're stored under.
+var cgroupStatKeys = map[string]string{
+	"anon": "CGroupAnon",
+	"file": "CGroupFile",
+	"slab": "CGroupSlab",
+}
+
+// addCgroupInfo populates i with the cgroup-v2 memory accounting found under
+// path, silently skipping any file that doesn't exist (e.g. swap accounting
+// disabled) so the rest of the tick's data stays usable.
+func addCgroupInfo(i Info, path string) {
+	readCgroupValue(i, path, "memory.current", "CGroupMemCurrent")
+	readCgroupValue(i, path, "memory.max", "CGroupMemMax")
+	readCgroupValue(i, path, "memory.swap.current", "CGroupSwapCurrent")
+	readCgroupValue(i, path, "memory.swap.max", "CGroupSwapMax")
+
+	f, err := fs.Open(filepath.Join(path, "memory.stat"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	s.Split(bufio.ScanLines)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		key, ok := cgroupStatKeys[fields[0]]
+		if !ok {
+			continue
+		}
+		if intval, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			i[key] = unit.Datasize(intval) * unit.Byte
+		}
+	}
+}
+
+// readCgroupValue reads a single-value cgroup accounting file (e.g.
+// memory.current) into i[key], treating the literal value "max" as "no
+// limit" and leaving the key unset.
+func readCgroupValue(i Info, dir, file, key string) {
+	f, err := fs.Open(filepath.Join(dir, file))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	raw, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && raw == "" {
+		return
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "max" {
+		return
+	}
+	intval, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return
+	}
+	i[key] = unit.Datasize(intval) * unit.Byte
+}
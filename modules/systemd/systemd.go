@@ -195,4 +195,98 @@ func getServiceInfo(w *dbus.PropertiesWatcher) ServiceInfo {
 	}
 	i.Type, _ = props[serviceIface+".Type"].(string)
 	return i
-}
\ No newline at end of file
+}
+
+// TimerInfo represents the state of a systemd timer.
+type TimerInfo struct {
+	UnitInfo
+	NextElapse    time.Time
+	LastTrigger   time.Time
+	TriggeredUnit string
+	Result        string
+}
+
+// TimerModule watches a systemd timer and updates on status change
+type TimerModule struct {
+	name       string
+	outputFunc value.Value
+}
+
+// Timer creates a module that watches the status of a systemd timer.
+func Timer(name string) *TimerModule {
+	t := &TimerModule{name: name}
+	t.Output(func(i TimerInfo) bar.Output {
+		if i.State == StateActive && !i.NextElapse.IsZero() {
+			return outputs.Textf("next in %v",
+				i.NextElapse.Sub(timing.Now()).Round(time.Second))
+		}
+		if i.LastTrigger.IsZero() {
+			return outputs.Textf("%s (%s)", i.State, i.SubState)
+		}
+		since := i.LastTrigger.Format("15:04")
+		if timing.Now().Add(-24 * time.Hour).After(i.LastTrigger) {
+			since = i.LastTrigger.Format("Jan 2")
+		}
+		return outputs.Textf("last run %s", since)
+	})
+	return t
+}
+
+// Output configures a module to display the output of a user-defined function.
+func (t *TimerModule) Output(outputFunc func(TimerInfo) bar.Output) *TimerModule {
+	t.outputFunc.Set(outputFunc)
+	return t
+}
+
+const timerIface = "org.freedesktop.systemd1.Timer"
+
+// Stream starts the module.
+func (t *TimerModule) Stream(sink bar.Sink) {
+	w := watchUnit(t.name + ".timer")
+	defer w.Unsubscribe()
+
+	w.FetchOnSignal(
+		timerIface+".NextElapseUSecRealtime",
+		timerIface+".NextElapseUSecMonotonic",
+		timerIface+".LastTriggerUSec",
+		timerIface+".Result",
+		timerIface+".Unit",
+	)
+
+	outputFunc := t.outputFunc.Get().(func(TimerInfo) bar.Output)
+	nextOutputFunc, done := t.outputFunc.Subscribe()
+	defer done()
+
+	info := getTimerInfo(w)
+	for {
+		sink.Output(outputFunc(info))
+		select {
+		case <-w.Updates:
+			info = getTimerInfo(w)
+		case <-nextOutputFunc:
+			outputFunc = t.outputFunc.Get().(func(TimerInfo) bar.Output)
+		}
+	}
+}
+
+func getTimerInfo(w *dbus.PropertiesWatcher) TimerInfo {
+	i := TimerInfo{}
+	var props map[string]interface{}
+	i.UnitInfo, props = getUnitInfo(w)
+	i.ID = strings.TrimSuffix(i.ID, ".timer")
+	// NextElapseUSecMonotonic is fetched so FetchOnSignal covers both clocks,
+	// but only the realtime value can be rendered as a wall-clock time.
+	if t, _ := props[timerIface+".NextElapseUSecRealtime"].(uint64); t > 0 {
+		sec := int64(t / usecInSec)
+		usec := int64(t % usecInSec)
+		i.NextElapse = time.Unix(sec, usec*1000 /* nsec */)
+	}
+	if t, _ := props[timerIface+".LastTriggerUSec"].(uint64); t > 0 {
+		sec := int64(t / usecInSec)
+		usec := int64(t % usecInSec)
+		i.LastTrigger = time.Unix(sec, usec*1000 /* nsec */)
+	}
+	i.TriggeredUnit, _ = props[timerIface+".Unit"].(string)
+	i.Result, _ = props[timerIface+".Result"].(string)
+	return i
+}
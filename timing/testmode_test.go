@@ -149,6 +149,44 @@ func TestAdvanceWithRepeated_TestMode(t *testing.T) {
 	}
 }
 
+func TestAdvanceWithTicker_TestMode(t *testing.T) {
+	TestMode()
+
+	tick := NewTicker(time.Second)
+
+	var launched sync.WaitGroup
+	var waited sync.WaitGroup
+	for i := 0; i < 60; i++ {
+		launched.Add(1)
+		waited.Add(1)
+		// Ensure that no writes to tick's channel will block,
+		// by adding listeners to the channel in advance.
+		go func() {
+			launched.Done()
+			<-tick.Chan()
+			waited.Done()
+		}()
+	}
+
+	launched.Wait() // ensure goroutines are launched.
+	AdvanceBy(time.Minute)
+
+	// If fewer than 60 ticks are received, this will never finish.
+	doneChan := make(chan struct{})
+	go func() {
+		waited.Wait()
+		doneChan <- struct{}{}
+	}()
+
+	select {
+	case <-doneChan: // Test passed.
+	case <-time.After(time.Second):
+		assert.Fail(t, "Did not receive 60 ticks")
+	}
+
+	tick.Stop()
+}
+
 func TestCoalescedUpdates_TestMode(t *testing.T) {
 	TestMode()
 
@@ -0,0 +1,49 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timing
+
+import "time"
+
+// Ticker mirrors the shape of a time.Ticker, so modules that want to drive
+// periodic work with idiomatic ticker semantics (rather than a Scheduler)
+// still get a test hook: under TestMode(), its ticks are delivered by the
+// logical clock instead of a real timer.
+type Ticker interface {
+	// Chan returns the channel on which ticks are delivered.
+	Chan() <-chan time.Time
+	// Stop turns off the ticker. No more ticks will be sent.
+	Stop()
+}
+
+// logicalTicker adapts a Scheduler to the Ticker interface. Scheduler
+// already registers with the same internal priority queue used by
+// AdvanceBy in test mode, and falls back to a real timer otherwise, so
+// delegating to it gives NewTicker both halves of that duality for free.
+type logicalTicker struct {
+	Scheduler
+}
+
+func (t logicalTicker) Chan() <-chan time.Time {
+	return t.Tick()
+}
+
+// NewTicker returns a Ticker that delivers the current time every d,
+// mirroring time.NewTicker. In production each tick comes from a real
+// timer; under TestMode(), AdvanceBy(d) delivers every intervening tick on
+// its channel, coalescing if the receiver is slow, matching the semantics
+// of a repeated Scheduler and of the standard library's Ticker.
+func NewTicker(d time.Duration) Ticker {
+	return logicalTicker{NewScheduler().Every(d)}
+}